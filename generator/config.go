@@ -0,0 +1,78 @@
+// Package generator discovers Go structs and renders them as TypeScript
+// via typescriptify. It is the engine behind the tscriptify command, but
+// is also usable directly by programs that want to embed struct-to-TS
+// generation in their own go:generate step.
+package generator
+
+import (
+	"io"
+	"regexp"
+)
+
+// Config controls a single generation run: which structs to discover,
+// how to discover them, and how the resulting TypeScript is produced.
+type Config struct {
+	// ModelsPackage is the directory containing the models, used as the
+	// base for resolving Structs' .go files and Packages' patterns.
+	ModelsPackage string
+
+	// TargetFile is the TypeScript file to write.
+	TargetFile string
+
+	// Structs holds literal, already-qualified struct references (e.g.
+	// "models.Person") and .go files to scan for exported structs.
+	Structs []string
+
+	// Packages holds Go package patterns (e.g. "./...", "./models/...",
+	// "example.com/foo/bar") to scan for exported structs.
+	Packages []string
+
+	// Recursive makes a pattern in Packages that doesn't already end in
+	// "..." also walk its subdirectories.
+	Recursive bool
+
+	// Include and Exclude, when non-nil, filter discovered struct names.
+	Include *regexp.Regexp
+	Exclude *regexp.Regexp
+
+	InitParams    map[string]interface{}
+	CustomImports []string
+	Interface     bool
+	Verbose       bool
+	ExtraImports  string
+	ExtraCommands string
+	BackupDir     string
+
+	// Writer receives verbose diagnostic output. Defaults to os.Stdout
+	// when nil.
+	Writer io.Writer
+
+	// Plugins customize a run; see the Plugin interface.
+	Plugins []Plugin
+}
+
+// Plugin lets callers of Generate customize a run. MutateConfig runs
+// before struct discovery and may adjust cfg in place, e.g. to inject
+// additional packages or type filters. GenerateCode runs after the
+// TypeScript file has been written, and may post-process its output or
+// run additional validation.
+type Plugin interface {
+	MutateConfig(cfg *Config) error
+	GenerateCode(data *CodegenData) error
+}
+
+// CodegenData is passed to Plugin.GenerateCode once a run completes.
+type CodegenData struct {
+	Config  *Config
+	Structs []string
+}
+
+// CompileFilter compiles pattern into a regexp for use as Config.Include
+// or Config.Exclude. An empty pattern yields a nil *regexp.Regexp, which
+// Generate treats as "no filter".
+func CompileFilter(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
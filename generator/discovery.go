@@ -0,0 +1,150 @@
+package generator
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// packageCache memoizes typed package loads within a single Generate
+// call, so multiple .go file arguments that share a directory only pay
+// the go/packages loading cost once. It's owned by the caller rather
+// than a package-level global, since Generate is a public API that may
+// be called concurrently from the same process.
+type packageCache map[string]*packages.Package
+
+func loadTypedPackage(cache packageCache, dir string) (*packages.Package, error) {
+	if pkg, ok := cache[dir]; ok {
+		return pkg, nil
+	}
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		return nil, err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("package %s has errors", dir)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected exactly one package in %s, got %d", dir, len(pkgs))
+	}
+	pkg := pkgs[0]
+	cache[dir] = pkg
+	return pkg, nil
+}
+
+// structsInFile returns the import path of the package rooted at dir,
+// along with the exported struct type names declared in file, a member
+// of that package. It type-checks the whole package rather than parsing
+// file alone, so that type aliases, embedded anonymous structs and
+// declarations in sibling files are all seen correctly. The returned
+// import path is the package's real, resolved path (e.g.
+// "example.com/foo/models"), not the filesystem directory, so callers
+// can generate an import the Go toolchain can actually resolve.
+func structsInFile(cache packageCache, dir, file string, include, exclude *regexp.Regexp) (pkgPath string, structs []string, err error) {
+	pkg, err := loadTypedPackage(cache, dir)
+	if err != nil {
+		return "", nil, err
+	}
+	structs, err = structsInScope(pkg, file, include, exclude)
+	if err != nil {
+		return "", nil, err
+	}
+	return pkg.PkgPath, structs, nil
+}
+
+// IsPackagePattern reports whether arg looks like a Go package pattern
+// (e.g. "./...", "./models/...", "example.com/foo/bar") rather than a
+// bare, already-resolved struct name such as "Person" or "m0.Person".
+// Callers building a Config from CLI-style positional arguments (as
+// tscriptify does) use this to split them between cfg.Packages and
+// cfg.Structs.
+func IsPackagePattern(arg string) bool {
+	return arg == "." || strings.ContainsRune(arg, '/') || strings.Contains(arg, "...")
+}
+
+// resolvePackagePatterns loads every package matching pattern, resolved
+// relative to dir. When recursive is set and pattern doesn't already end
+// in "...", its subdirectories are walked too.
+func resolvePackagePatterns(dir, pattern string, recursive bool) ([]*packages.Package, error) {
+	if recursive && !strings.HasSuffix(pattern, "...") {
+		// filepath.Join would clean away a leading "./", turning it
+		// into a bare "..." that scans the entire module/GOPATH
+		// instead of just pattern's subtree.
+		pattern = strings.TrimSuffix(pattern, "/") + "/..."
+	}
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedSyntax,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("pattern %q has errors", pattern)
+	}
+	return pkgs, nil
+}
+
+// structsInScope returns the exported struct type names in pkg's package
+// scope. When file is non-empty, only structs declared in that file are
+// returned. include/exclude, when non-nil, further filter struct names.
+// Generic struct types are skipped, with a clear warning on stderr,
+// since typescriptify has no way to instantiate their type parameters;
+// they don't block discovery of the rest of the package.
+func structsInScope(pkg *packages.Package, file string, include, exclude *regexp.Regexp) ([]string, error) {
+	var absFile string
+	if file != "" {
+		var err error
+		absFile, err = filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	scope := pkg.Types.Scope()
+	names := scope.Names()
+	sort.Strings(names)
+
+	var structs []string
+	for _, name := range names {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || !obj.Exported() {
+			continue
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			continue
+		}
+		if absFile != "" {
+			if pos := pkg.Fset.Position(obj.Pos()); pos.Filename != absFile {
+				continue
+			}
+		}
+		if exclude != nil && exclude.MatchString(name) {
+			continue
+		}
+		if include != nil && !include.MatchString(name) {
+			continue
+		}
+		if named.TypeParams().Len() > 0 {
+			fmt.Fprintf(os.Stderr, "skipping %s: generic struct; typescriptify cannot generate a TypeScript type for it\n", name)
+			continue
+		}
+		structs = append(structs, name)
+	}
+	return structs, nil
+}
@@ -0,0 +1,188 @@
+package generator
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIsPackagePattern(t *testing.T) {
+	cases := []struct {
+		arg  string
+		want bool
+	}{
+		{".", true},
+		{"./...", true},
+		{"./models/...", true},
+		{"example.com/foo/bar", true},
+		{"Person", false},
+		{"m0.Person", false},
+	}
+	for _, tc := range cases {
+		if got := IsPackagePattern(tc.arg); got != tc.want {
+			t.Errorf("IsPackagePattern(%q) = %v, want %v", tc.arg, got, tc.want)
+		}
+	}
+}
+
+func TestResolvePackagePatternsRecursive(t *testing.T) {
+	dir := "testdata/modroot"
+
+	cases := []struct {
+		name      string
+		pattern   string
+		recursive bool
+		want      []string
+	}{
+		{
+			name:    "non-recursive directory pattern scans only that package",
+			pattern: "./models",
+			want:    []string{"example.com/modroot/models"},
+		},
+		{
+			name:      "recursive directory pattern walks subdirectories",
+			pattern:   "./models",
+			recursive: true,
+			want: []string{
+				"example.com/modroot/models",
+				"example.com/modroot/models/nested",
+			},
+		},
+		{
+			name:      "recursive dot pattern stays scoped to dir",
+			pattern:   ".",
+			recursive: true,
+			want: []string{
+				"example.com/modroot/models",
+				"example.com/modroot/models/nested",
+				"example.com/modroot/funcs",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pkgs, err := resolvePackagePatterns(dir, tc.pattern, tc.recursive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]bool{}
+			for _, pkg := range pkgs {
+				got[pkg.PkgPath] = true
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("resolvePackagePatterns(%q, recursive=%v) = %v, want %v", tc.pattern, tc.recursive, got, tc.want)
+			}
+			for _, w := range tc.want {
+				if !got[w] {
+					t.Errorf("resolvePackagePatterns(%q, recursive=%v) missing %s, got %v", tc.pattern, tc.recursive, w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverStructsGoFileUsesRealImportPath(t *testing.T) {
+	cfg := &Config{
+		ModelsPackage: "testdata/modroot",
+		Structs:       []string{"models/models.go"},
+	}
+
+	names, imports, err := discoverStructs(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "m0.Person" {
+		t.Fatalf("got names %v, want [m0.Person]", names)
+	}
+	if _, ok := imports["example.com/modroot/models"]; !ok {
+		t.Fatalf("got imports %v, want an entry for example.com/modroot/models, not the filesystem directory", imports)
+	}
+}
+
+func TestDiscoverStructsGoFileSkipsGenericSiblingStruct(t *testing.T) {
+	cfg := &Config{
+		ModelsPackage: "testdata/modroot",
+		Structs:       []string{"models/models.go"},
+	}
+
+	names, _, err := discoverStructs(cfg)
+	if err != nil {
+		t.Fatalf("a generic struct in a sibling file must not abort discovery: %v", err)
+	}
+	if len(names) != 1 || names[0] != "m0.Person" {
+		t.Fatalf("got names %v, want [m0.Person]", names)
+	}
+}
+
+func TestDiscoverStructsPackagePatternSkipsGenericStruct(t *testing.T) {
+	cfg := &Config{
+		ModelsPackage: "testdata/modroot",
+		Packages:      []string{"./models"},
+	}
+
+	names, _, err := discoverStructs(cfg)
+	if err != nil {
+		t.Fatalf("a generic struct must be skipped, not abort the whole package scan: %v", err)
+	}
+	if len(names) != 1 || names[0] != "m0.Person" {
+		t.Fatalf("got names %v, want [m0.Person] (Box is generic and must be skipped)", names)
+	}
+}
+
+func TestDiscoverStructsConcurrentCallsDontShareCache(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg := &Config{
+				ModelsPackage: "testdata/modroot",
+				Structs:       []string{"models/models.go"},
+			}
+			names, _, err := discoverStructs(cfg)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(names) != 1 || names[0] != "m0.Person" {
+				t.Errorf("got names %v, want [m0.Person]", names)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDiscoverStructsSkipsPackagesWithNoStructs(t *testing.T) {
+	cfg := &Config{
+		ModelsPackage: "testdata/modroot",
+		Packages:      []string{"./..."},
+	}
+
+	names, imports, err := discoverStructs(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantNames := map[string]bool{}
+	for _, n := range names {
+		wantNames[n] = true
+	}
+	if len(names) != 2 {
+		t.Fatalf("got structs %v, want exactly Person and Note", names)
+	}
+	var sawPerson, sawNote bool
+	for n := range wantNames {
+		sawPerson = sawPerson || strings.HasSuffix(n, ".Person")
+		sawNote = sawNote || strings.HasSuffix(n, ".Note")
+	}
+	if !sawPerson || !sawNote {
+		t.Fatalf("got structs %v, want exactly Person and Note", names)
+	}
+
+	for path := range imports {
+		if path == "example.com/modroot/funcs" {
+			t.Fatalf("funcs package has no structs and must not be imported, got imports %v", imports)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// templateParams is the data rendered into the throwaway Go program that
+// calls typescriptify.
+type templateParams struct {
+	Models        string
+	Interface     bool
+	InitParams    map[string]interface{}
+	Structs       []string
+	CustomImports []string
+	ExtraImports  string
+	ExtraCommands string
+	TargetFile    string
+}
+
+// Generate discovers the structs described by cfg, renders them through
+// typescriptify, and writes the result to cfg.TargetFile.
+func Generate(ctx context.Context, cfg *Config) error {
+	for _, p := range cfg.Plugins {
+		if err := p.MutateConfig(cfg); err != nil {
+			return fmt.Errorf("mutate config: %w", err)
+		}
+	}
+
+	if cfg.ModelsPackage == "" {
+		return fmt.Errorf("no package given")
+	}
+	if cfg.TargetFile == "" {
+		return fmt.Errorf("no target file given")
+	}
+
+	w := cfg.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+
+	names, imports, err := discoverStructs(cfg)
+	if err != nil {
+		return err
+	}
+
+	initParams := map[string]interface{}{}
+	for k, v := range cfg.InitParams {
+		initParams[k] = v
+	}
+	initParams["BackupDir"] = fmt.Sprintf("%q", cfg.BackupDir)
+
+	tp := templateParams{
+		Interface:     cfg.Interface,
+		InitParams:    initParams,
+		Structs:       names,
+		CustomImports: cfg.CustomImports,
+		TargetFile:    cfg.TargetFile,
+	}
+
+	if cfg.ExtraImports != "" {
+		byt, err := os.ReadFile(cfg.ExtraImports)
+		if err != nil {
+			return err
+		}
+		tp.ExtraImports = string(byt)
+	}
+	if cfg.ExtraCommands != "" {
+		byt, err := os.ReadFile(cfg.ExtraCommands)
+		if err != nil {
+			return err
+		}
+		tp.ExtraCommands = string(byt)
+	}
+
+	models := make([]string, 0, len(imports))
+	for path, n := range imports {
+		models = append(models, fmt.Sprintf("m%d %q", n, path))
+	}
+	tp.Models = strings.Join(models, "\n\t")
+
+	if err := render(ctx, tp, cfg.Verbose, w); err != nil {
+		return err
+	}
+
+	data := &CodegenData{Config: cfg, Structs: names}
+	for _, p := range cfg.Plugins {
+		if err := p.GenerateCode(data); err != nil {
+			return fmt.Errorf("generate code: %w", err)
+		}
+	}
+	return nil
+}
+
+// discoverStructs resolves cfg.Structs and cfg.Packages into a
+// deterministic list of struct references (e.g. "m0.Person") ready for
+// the generated t.Add(...) calls, along with the package-path-to-alias
+// mapping needed to import them.
+func discoverStructs(cfg *Config) (names []string, imports map[string]int, err error) {
+	imports = map[string]int{}
+	alias := func(key string) int {
+		n, ok := imports[key]
+		if !ok {
+			n = len(imports)
+			imports[key] = n
+		}
+		return n
+	}
+
+	cache := packageCache{}
+	for _, structOrGoFile := range cfg.Structs {
+		if strings.HasSuffix(structOrGoFile, ".go") {
+			fullFile := filepath.Join(cfg.ModelsPackage, structOrGoFile)
+			dir := filepath.Dir(fullFile)
+			pkgPath, fileStructs, err := structsInFile(cache, dir, fullFile, cfg.Include, cfg.Exclude)
+			if err != nil {
+				return nil, nil, fmt.Errorf("load %s: %w", structOrGoFile, err)
+			}
+			if len(fileStructs) == 0 {
+				// A file with no exported structs (or none left after
+				// -include/-exclude) must not be imported, or the
+				// generated program fails with "imported and not used".
+				continue
+			}
+			n := alias(pkgPath)
+			for _, s := range fileStructs {
+				names = append(names, fmt.Sprintf("m%d.%s", n, s))
+			}
+			continue
+		}
+		str := strings.TrimSpace(structOrGoFile)
+		if str == "" || strings.HasPrefix(str, ".") || strings.Contains(str, string(filepath.Separator)) {
+			continue
+		}
+		names = append(names, str)
+	}
+
+	for _, pattern := range cfg.Packages {
+		pkgs, err := resolvePackagePatterns(cfg.ModelsPackage, pattern, cfg.Recursive)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load package pattern %q: %w", pattern, err)
+		}
+		for _, pkg := range pkgs {
+			pkgStructs, err := structsInScope(pkg, "", cfg.Include, cfg.Exclude)
+			if err != nil {
+				return nil, nil, fmt.Errorf("load package %s: %w", pkg.PkgPath, err)
+			}
+			if len(pkgStructs) == 0 {
+				// A package with no exported structs (or none left
+				// after -include/-exclude) must not be imported, or
+				// the generated program fails with "imported and not
+				// used".
+				continue
+			}
+			n := alias(pkg.PkgPath)
+			for _, s := range pkgStructs {
+				names = append(names, fmt.Sprintf("m%d.%s", n, s))
+			}
+		}
+	}
+
+	return names, imports, nil
+}
@@ -0,0 +1,87 @@
+//go:build !race
+
+// Go's plugin buildmode requires the plugin and the host binary to be
+// built with identical flags, and go test -race rebuilds the host
+// binary with the race detector enabled while renderViaPlugin compiles
+// the plugin without it; the resulting plugin.Open always fails under
+// -race regardless of the code under test, so this file is excluded
+// from race builds.
+
+package generator
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// recordingPlugin records the CodegenData its GenerateCode receives, so
+// tests can assert Generate actually ran the Plugin hooks and passed
+// through the data a real plugin (e.g. a TS lint step) would need.
+type recordingPlugin struct {
+	mutated bool
+	data    *CodegenData
+}
+
+func (p *recordingPlugin) MutateConfig(cfg *Config) error {
+	p.mutated = true
+	return nil
+}
+
+func (p *recordingPlugin) GenerateCode(data *CodegenData) error {
+	p.data = data
+	return nil
+}
+
+// TestGenerateRunsPluginHooks drives Generate end-to-end against a real
+// module fixture (testdata/gentest, which depends on the real
+// typescriptify library) and asserts both Plugin hooks ran and the
+// generated TypeScript file was actually written.
+func TestGenerateRunsPluginHooks(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("testdata/gentest"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	out := "out.ts"
+	defer os.Remove(out)
+
+	plug := &recordingPlugin{}
+	cfg := &Config{
+		ModelsPackage: ".",
+		TargetFile:    out,
+		Packages:      []string{"./models"},
+		Writer:        os.Stdout,
+		Plugins:       []Plugin{plug},
+	}
+
+	if err := Generate(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if !plug.mutated {
+		t.Error("MutateConfig was never called")
+	}
+	if plug.data == nil {
+		t.Fatal("GenerateCode was never called")
+	}
+	if len(plug.data.Structs) != 1 || !strings.HasSuffix(plug.data.Structs[0], "Person") {
+		t.Errorf("got CodegenData.Structs %v, want exactly one Person reference", plug.data.Structs)
+	}
+	if plug.data.Config != cfg {
+		t.Error("CodegenData.Config is not the same Config passed to Generate")
+	}
+
+	byt, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(byt), "Person") {
+		t.Errorf("generated %s does not mention Person:\n%s", out, byt)
+	}
+}
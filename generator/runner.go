@@ -0,0 +1,203 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"os/exec"
+	"plugin"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+// sharedDecls are the helper types shared by both the plugin- and
+// exec-mode templates.
+const sharedDecls = `
+type enum[T any] struct {
+	Value  T
+	TSName string
+}
+
+func stringEnum[T ~string](xs []T) []enum[T] {
+	out := make([]enum[T], 0, len(xs))
+	for _, x := range xs {
+		out = append(out, enum[T]{
+			Value: x,
+			TSName: string(x),
+		})
+	}
+	return out
+}`
+
+// pluginTmpl renders a Go plugin (package main, buildmode=plugin)
+// exporting Run, which the host loads in-process via plugin.Open/Lookup.
+// The plugin is still compiled with "go build" on every invocation; what
+// it avoids is exec'ing a separate generated binary to do the conversion.
+const pluginTmpl = `package main
+
+import (
+	{{ .Models }}
+	"github.com/tkrajina/typescriptify-golang-structs/typescriptify"
+{{ .ExtraImports }}
+)
+
+func Run() error {
+	t := typescriptify.New()
+	t.CreateInterface = {{ .Interface }}
+{{ range $key, $value := .InitParams }}	t.{{ $key }}={{ $value }}
+{{ end }}
+{{ range .Structs }}	t.Add({{ . }}{})
+{{ end }}
+{{ range .CustomImports }}	t.AddImport("{{ . }}")
+{{ end }}
+	{{ .ExtraCommands }}
+	return t.ConvertToFile("{{ .TargetFile }}")
+}
+` + sharedDecls
+
+// execTmpl renders a standalone "go run" program. It's the fallback used
+// on Windows, where Go's plugin buildmode isn't supported.
+const execTmpl = `package main
+
+import (
+	{{ .Models }}
+	"github.com/tkrajina/typescriptify-golang-structs/typescriptify"
+{{ .ExtraImports }}
+)
+
+func main() {
+	t := typescriptify.New()
+	t.CreateInterface = {{ .Interface }}
+{{ range $key, $value := .InitParams }}	t.{{ $key }}={{ $value }}
+{{ end }}
+{{ range .Structs }}	t.Add({{ . }}{})
+{{ end }}
+{{ range .CustomImports }}	t.AddImport("{{ . }}")
+{{ end }}
+	{{ .ExtraCommands }}
+	err := t.ConvertToFile("{{ .TargetFile }}")
+	if err != nil {
+		panic(err.Error())
+	}
+}
+` + sharedDecls
+
+// render produces the TypeScript described by tp. It prefers compiling
+// and loading a Go plugin in-process, falling back to "go run" on
+// Windows. Every other GOOS takes the plugin path, so a platform without
+// plugin support fails there instead of falling back.
+func render(ctx context.Context, tp templateParams, verbose bool, w io.Writer) error {
+	if runtime.GOOS == "windows" {
+		return renderViaExec(ctx, tp, verbose, w)
+	}
+	return renderViaPlugin(ctx, tp, verbose, w)
+}
+
+// renderViaPlugin compiles the generated program as a Go plugin and
+// invokes its exported Run function in-process, so struct-to-TypeScript
+// conversion happens as a direct function call instead of through a
+// separate compiled-and-exec'd process.
+func renderViaPlugin(ctx context.Context, tp templateParams, verbose bool, w io.Writer) error {
+	srcFile, err := writeTempSource(pluginTmpl, tp, "plugin source", verbose, w)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(srcFile)
+
+	soFile := strings.TrimSuffix(srcFile, ".go") + ".so"
+	defer os.Remove(soFile)
+
+	cmd := exec.CommandContext(ctx, "go", "build", "-buildmode=plugin", "-o", soFile, srcFile)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		fmt.Fprintln(w, string(output))
+		return fmt.Errorf("build plugin: %w", err)
+	}
+
+	p, err := plugin.Open(soFile)
+	if err != nil {
+		return fmt.Errorf("open plugin: %w", err)
+	}
+	sym, err := p.Lookup("Run")
+	if err != nil {
+		return fmt.Errorf("look up Run in plugin: %w", err)
+	}
+	run, ok := sym.(func() error)
+	if !ok {
+		return fmt.Errorf("plugin %s: Run has an unexpected signature", soFile)
+	}
+	return run()
+}
+
+// renderViaExec renders tp into a throwaway Go program and runs it with
+// `go run`, since the generated program is what actually calls
+// typescriptify and writes the target file.
+func renderViaExec(ctx context.Context, tp templateParams, verbose bool, w io.Writer) error {
+	srcFile, err := writeTempSource(execTmpl, tp, "generated code", verbose, w)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(srcFile)
+
+	cmd := exec.CommandContext(ctx, "go", "run", srcFile)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Fprintln(w, string(output))
+		return err
+	}
+	return nil
+}
+
+// writeTempSource renders tmplSrc into a new temp .go file and returns
+// its path, optionally logging the formatted source to w under label.
+// The rendered source is run through go/format.Source before being
+// written, so that a syntactically malformed template expansion (a bad
+// struct name, malformed ExtraCommands) is reported as a clear
+// formatting error with line numbers, rather than surfacing later as a
+// cryptic compiler error against an unformatted temp file. format.Source
+// checks syntax only: type errors such as an unknown import alias still
+// surface when the source is compiled.
+func writeTempSource(tmplSrc string, tp templateParams, label string, verbose bool, w io.Writer) (string, error) {
+	t := template.Must(template.New("").Parse(tmplSrc))
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, tp); err != nil {
+		return "", err
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("format %s: %w\n%s", label, err, annotateLines(buf.Bytes()))
+	}
+
+	f, err := os.CreateTemp(os.TempDir(), "typescriptify_*.go")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(formatted); err != nil {
+		return "", err
+	}
+
+	if verbose {
+		fmt.Fprintf(w, "\nCompiling %s (%s):\n%s\n----------------------------------------------------------------------------------------------------\n", label, f.Name(), string(formatted))
+	}
+
+	return f.Name(), nil
+}
+
+// annotateLines prefixes each line of src with its 1-based line number,
+// so a formatting error against the unformatted template expansion
+// points straight at the offending line.
+func annotateLines(src []byte) string {
+	lines := strings.Split(string(src), "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%4d| %s\n", i+1, line)
+	}
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package generator
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestWriteTempSourceAnnotatesFormatError exercises the format.Source
+// error path directly: a malformed struct reference produces invalid Go
+// source, and writeTempSource must report it as a line-annotated
+// formatting error rather than letting it surface as a cryptic compiler
+// error against an unformatted temp file.
+func TestWriteTempSourceAnnotatesFormatError(t *testing.T) {
+	tp := templateParams{
+		Structs:    []string{"not a valid struct ref"},
+		TargetFile: "out.ts",
+	}
+
+	_, err := writeTempSource(execTmpl, tp, "generated code", false, os.Stdout)
+	if err == nil {
+		t.Fatal("expected a format error for a malformed struct reference")
+	}
+	if !strings.Contains(err.Error(), "format generated code") {
+		t.Errorf("error %q does not identify the generated code as the source of the failure", err)
+	}
+	if !strings.Contains(err.Error(), "   1| ") {
+		t.Errorf("error %q is not annotated with 1-based line numbers", err)
+	}
+}
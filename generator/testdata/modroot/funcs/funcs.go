@@ -0,0 +1,3 @@
+package funcs
+
+func Add(a, b int) int { return a + b }
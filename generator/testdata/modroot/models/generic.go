@@ -0,0 +1,5 @@
+package models
+
+type Box[T any] struct {
+	Value T
+}
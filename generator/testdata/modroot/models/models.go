@@ -0,0 +1,5 @@
+package models
+
+type Person struct {
+	Name string
+}
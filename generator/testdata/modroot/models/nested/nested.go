@@ -0,0 +1,5 @@
+package nested
+
+type Note struct {
+	Body string
+}